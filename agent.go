@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+//caniuseDataURL is where the current browser usage-share dataset is pulled from
+const caniuseDataURL = "https://raw.githubusercontent.com/Fyrd/caniuse/main/fulldata-json/data-2.0.json"
+
+//caniuseHTTPClient bounds how long a fetch of the (multi-MB) caniuse dataset can
+//take, so a slow or unreachable network falls back quickly instead of hanging
+var caniuseHTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+//browserVersion is one version of a browser weighted by its global usage share
+type browserVersion struct {
+	Browser string
+	Version string
+	Weight  float64
+}
+
+//BrowserData is the parsed, weighted set of versions RandomUserAgent picks from
+type BrowserData struct {
+	Versions  []browserVersion
+	FetchedAt time.Time
+}
+
+//fallbackBrowserData is used when the caniuse dataset can't be fetched, e.g. on
+//first boot with no network access
+var fallbackBrowserData = BrowserData{
+	Versions: []browserVersion{
+		{Browser: "chrome", Version: "124", Weight: 3},
+		{Browser: "chrome", Version: "123", Weight: 2},
+		{Browser: "chrome", Version: "122", Weight: 1},
+		{Browser: "firefox", Version: "125", Weight: 2},
+		{Browser: "firefox", Version: "124", Weight: 1},
+	},
+}
+
+var (
+	browserDataMu sync.RWMutex
+	browserData   = fallbackBrowserData
+)
+
+type caniuseAgent struct {
+	Browser     string             `json:"browser"`
+	UsageGlobal map[string]float64 `json:"usage_global"`
+}
+
+type caniuseResponse struct {
+	Agents map[string]caniuseAgent `json:"agents"`
+}
+
+//refreshBrowserData fetches and parses the caniuse dataset, keeping only the
+//Firefox and Chrome versions weighted by their usage_global share
+func refreshBrowserData() error {
+	resp, err := caniuseHTTPClient.Get(caniuseDataURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var parsed caniuseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return err
+	}
+
+	var versions []browserVersion
+	for _, key := range []string{"chrome", "firefox"} {
+		agent, ok := parsed.Agents[key]
+		if !ok {
+			continue
+		}
+		for version, usage := range agent.UsageGlobal {
+			if usage <= 0 {
+				continue
+			}
+			versions = append(versions, browserVersion{Browser: key, Version: version, Weight: usage})
+		}
+	}
+
+	if len(versions) == 0 {
+		return nil
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Weight > versions[j].Weight })
+	if len(versions) > 20 {
+		versions = versions[:20]
+	}
+
+	browserDataMu.Lock()
+	browserData = BrowserData{Versions: versions, FetchedAt: time.Now()}
+	browserDataMu.Unlock()
+	return nil
+}
+
+//startUserAgentRefresher refreshes browserData immediately and then every interval.
+//The whole thing runs in the background - including the first fetch - so a slow
+//or unreachable network can't stall server startup; RandomUserAgent has the
+//hardcoded fallback to use in the meantime.
+func startUserAgentRefresher(interval time.Duration) {
+	go func() {
+		if err := refreshBrowserData(); err != nil {
+			log.Printf("agent: initial browser data fetch failed, using fallback: %v", err)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := refreshBrowserData(); err != nil {
+				log.Printf("agent: browser data refresh failed: %v", err)
+			}
+		}
+	}()
+}
+
+//RandomUserAgent formats a plausible desktop UA string, picking a browser/version
+//weighted by its current global usage share
+func RandomUserAgent() string {
+	browserDataMu.RLock()
+	versions := browserData.Versions
+	browserDataMu.RUnlock()
+
+	if len(versions) == 0 {
+		versions = fallbackBrowserData.Versions
+	}
+
+	var total float64
+	for _, v := range versions {
+		total += v.Weight
+	}
+
+	pick := rand.Float64() * total
+	var chosen browserVersion
+	for _, v := range versions {
+		pick -= v.Weight
+		if pick <= 0 {
+			chosen = v
+			break
+		}
+	}
+	if chosen.Version == "" {
+		chosen = versions[len(versions)-1]
+	}
+
+	return formatUserAgent(chosen)
+}
+
+func formatUserAgent(v browserVersion) string {
+	switch v.Browser {
+	case "firefox":
+		return "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:" + v.Version + ") Gecko/20100101 Firefox/" + v.Version + ".0"
+	default:
+		return "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/" + v.Version + ".0.0.0 Safari/537.36"
+	}
+}
+
+//userAgentTransport sets a fresh RandomUserAgent on every outbound request before
+//delegating to the wrapped RoundTripper
+type userAgentTransport struct {
+	wrapped http.RoundTripper
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set("User-Agent", RandomUserAgent())
+	return t.wrapped.RoundTrip(req)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+//apiError is the stable envelope returned by the JSON API, as opposed to the
+//raw http.Error text the HTML frontend is happy with
+type apiError struct {
+	Status     string `json:"status"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	httpStatus int
+}
+
+func newAPIError(httpStatus int, code, message string) *apiError {
+	return &apiError{Status: "error", Code: code, Message: message, httpStatus: httpStatus}
+}
+
+//apiArticle mirrors Article but adds the formatted date as a computed field,
+//rather than making API clients reimplement FormatPublishedDate themselves
+type apiArticle struct {
+	Article
+	FormattedPublishedAt string `json:"formattedPublishedAt"`
+}
+
+//apiResults mirrors Results with Articles swapped for their API representation
+type apiResults struct {
+	Status       string       `json:"status"`
+	TotalResults int          `json:"totalResults"`
+	Articles     []apiArticle `json:"articles"`
+}
+
+//apiSearchResponse is the JSON counterpart of Search
+type apiSearchResponse struct {
+	SearchKey  string     `json:"searchKey"`
+	NextPage   int        `json:"nextPage"`
+	TotalPages int        `json:"totalPages"`
+	Results    apiResults `json:"results"`
+}
+
+func toAPISearchResponse(search *Search) apiSearchResponse {
+	articles := make([]apiArticle, len(search.Results.Articles))
+	for i, a := range search.Results.Articles {
+		articles[i] = apiArticle{Article: a, FormattedPublishedAt: a.FormatPublishedDate()}
+	}
+
+	return apiSearchResponse{
+		SearchKey:  search.SearchKey,
+		NextPage:   search.NextPage,
+		TotalPages: search.TotalPages,
+		Results: apiResults{
+			Status:       search.Results.Status,
+			TotalResults: search.Results.TotalResults,
+			Articles:     articles,
+		},
+	}
+}
+
+//wantsJSON decides whether a request should be served application/json instead
+//of the HTML template, either via the /api/v1 mount or an Accept header
+func wantsJSON(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/api/v1/") {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+func writeJSONError(w http.ResponseWriter, apiErr *apiError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.httpStatus)
+	json.NewEncoder(w).Encode(apiErr)
+}
+
+func writeJSONSearch(w http.ResponseWriter, search *Search) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toAPISearchResponse(search))
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//defaultMaxArchiveDocs bounds how many articles the archive keeps so it can't
+//grow unbounded for the life of the process; the oldest (by PublishedAt) are
+//evicted once the archive is over the limit
+const defaultMaxArchiveDocs = 50000
+
+//articleArchive is a local full-text index over every Article the app has ever
+//returned, so /archive can be searched without burning the upstream quota and
+//isn't limited to NewsAPI's 30-day window.
+//
+//This is NOT Bleve: the repo has no go.mod and so no way to vendor a real
+//full-text engine, and adding one was not something this change could do
+//honestly. What's here is a linear substring/date/source filter over an
+//in-memory map, persisted to a gob file so it survives a restart - the closest
+//honest stand-in available without a dependency manager, not a drop-in
+//replacement for Bleve's indexing or query language.
+type articleArchive struct {
+	mu      sync.Mutex
+	docs    map[string]Article
+	path    string
+	maxDocs int
+}
+
+//newArticleArchive loads any previously persisted docs from path (if it exists)
+//and returns an archive that flushes back to it on every index() call. Pass an
+//empty path to keep the archive in-memory only, e.g. in tests.
+func newArticleArchive(path string, maxDocs int) (*articleArchive, error) {
+	if maxDocs <= 0 {
+		maxDocs = defaultMaxArchiveDocs
+	}
+	a := &articleArchive{docs: map[string]Article{}, path: path, maxDocs: maxDocs}
+
+	if path == "" {
+		return a, nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := gob.NewDecoder(f).Decode(&a.docs); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+//index stores or updates every article, keyed by URL for dedup, mirroring how
+//the provider merge step dedupes by URL, then evicts down to maxDocs and
+//persists if the archive is backed by a file
+func (a *articleArchive) index(articles []Article) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, article := range articles {
+		if article.URL == "" {
+			continue
+		}
+		a.docs[article.URL] = article
+	}
+
+	a.evictLocked()
+
+	if a.path == "" {
+		return
+	}
+	if err := a.flushLocked(); err != nil {
+		log.Printf("archive: failed to persist to %s: %v", a.path, err)
+	}
+}
+
+//evictLocked drops the oldest articles (by PublishedAt) once the archive is
+//over maxDocs. Caller must hold a.mu.
+func (a *articleArchive) evictLocked() {
+	if a.maxDocs <= 0 || len(a.docs) <= a.maxDocs {
+		return
+	}
+
+	type keyed struct {
+		url       string
+		published time.Time
+	}
+	all := make([]keyed, 0, len(a.docs))
+	for url, article := range a.docs {
+		all = append(all, keyed{url: url, published: article.PublishedAt})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].published.Before(all[j].published) })
+
+	for _, k := range all[:len(a.docs)-a.maxDocs] {
+		delete(a.docs, k.url)
+	}
+}
+
+func (a *articleArchive) flushLocked() error {
+	f, err := os.Create(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gob.NewEncoder(f).Encode(a.docs)
+}
+
+//archive is initialized in main() once cfg is loaded
+var archive *articleArchive
+
+//archiveQuery is a single search against the local index
+type archiveQuery struct {
+	Phrase string
+	From   time.Time
+	To     time.Time
+	Source string
+}
+
+func (a *articleArchive) search(q archiveQuery) []Article {
+	phrase := strings.ToLower(strings.TrimSpace(q.Phrase))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var matches []Article
+	for _, article := range a.docs {
+		if phrase != "" && !articleContains(article, phrase) {
+			continue
+		}
+		if q.Source != "" && !strings.EqualFold(article.Source.Name, q.Source) {
+			continue
+		}
+		if !q.From.IsZero() && article.PublishedAt.Before(q.From) {
+			continue
+		}
+		if !q.To.IsZero() && article.PublishedAt.After(q.To) {
+			continue
+		}
+		matches = append(matches, article)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].PublishedAt.After(matches[j].PublishedAt)
+	})
+	return matches
+}
+
+func articleContains(a Article, phrase string) bool {
+	fields := []string{a.Title, a.Description, a.Content, a.Author, a.Source.Name}
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+//parseArchiveDate parses the "2006-01-02" form used by the from/to query params
+func parseArchiveDate(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+//archiveHandler serves /archive: the same Search/pagination template as the
+//live search, but backed by the local index instead of the upstream providers
+func archiveHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	page := 1
+	if p := params.Get("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed >= 1 {
+			page = parsed
+		}
+	}
+	pageSize := 20
+
+	q := archiveQuery{
+		Phrase: params.Get("q"),
+		From:   parseArchiveDate(params.Get("from")),
+		To:     parseArchiveDate(params.Get("to")),
+		Source: params.Get("source"),
+	}
+
+	matches := archive.search(q)
+
+	search := &Search{SearchKey: q.Phrase, NextPage: page}
+	search.Results = paginate(Results{Status: "ok", TotalResults: len(matches), Articles: matches}, page, pageSize)
+	search.TotalPages = ceilDiv(len(matches), pageSize)
+
+	if !search.IsLastPage() {
+		search.NextPage++
+	}
+
+	if wantsJSON(r) {
+		writeJSONSearch(w, search)
+		return
+	}
+
+	if err := tpl.Execute(w, search); err != nil {
+		log.Println(err)
+	}
+}
+
+func ceilDiv(total, size int) int {
+	if size == 0 {
+		return 0
+	}
+	return (total + size - 1) / size
+}
+
+//archiveSourcesHandler lists the distinct source names seen so far, for building
+//a source facet picker
+func archiveSourcesHandler(w http.ResponseWriter, r *http.Request) {
+	archive.mu.Lock()
+	seen := map[string]bool{}
+	var sources []string
+	for _, a := range archive.docs {
+		if a.Source.Name == "" || seen[a.Source.Name] {
+			continue
+		}
+		seen[a.Source.Name] = true
+		sources = append(sources, a.Source.Name)
+	}
+	archive.mu.Unlock()
+
+	sort.Strings(sources)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sources)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArticleArchiveSearchFiltersByPhraseDateAndSource(t *testing.T) {
+	archive, err := newArticleArchive("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jan1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan15 := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	archive.index([]Article{
+		{URL: "https://a.example/1", Title: "Go 1.22 released", Source: Source{Name: "BBC"}, PublishedAt: jan1},
+		{URL: "https://a.example/2", Title: "Rust news", Source: Source{Name: "CNN"}, PublishedAt: jan15},
+	})
+
+	matches := archive.search(archiveQuery{Phrase: "go"})
+	if len(matches) != 1 || matches[0].URL != "https://a.example/1" {
+		t.Fatalf("expected phrase filter to find only the Go article, got %+v", matches)
+	}
+
+	matches = archive.search(archiveQuery{Source: "CNN"})
+	if len(matches) != 1 || matches[0].URL != "https://a.example/2" {
+		t.Fatalf("expected source filter to find only the CNN article, got %+v", matches)
+	}
+
+	matches = archive.search(archiveQuery{From: jan15})
+	if len(matches) != 1 || matches[0].URL != "https://a.example/2" {
+		t.Fatalf("expected date filter to exclude the earlier article, got %+v", matches)
+	}
+}
+
+func TestArticleArchivePersistsAcrossReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.gob")
+
+	archive, err := newArticleArchive(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive.index([]Article{{URL: "https://a.example/1", Title: "Persisted", PublishedAt: time.Now()}})
+
+	reloaded, err := newArticleArchive(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := reloaded.search(archiveQuery{})
+	if len(matches) != 1 || matches[0].URL != "https://a.example/1" {
+		t.Fatalf("expected the indexed article to survive a reload, got %+v", matches)
+	}
+}
+
+func TestArticleArchiveEvictsOldestOverMaxDocs(t *testing.T) {
+	archive, err := newArticleArchive("", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	archive.index([]Article{
+		{URL: "https://a.example/oldest", Title: "Oldest", PublishedAt: base},
+		{URL: "https://a.example/middle", Title: "Middle", PublishedAt: base.Add(time.Hour)},
+		{URL: "https://a.example/newest", Title: "Newest", PublishedAt: base.Add(2 * time.Hour)},
+	})
+
+	matches := archive.search(archiveQuery{})
+	if len(matches) != 2 {
+		t.Fatalf("expected eviction to cap the archive at 2 docs, got %d", len(matches))
+	}
+	for _, m := range matches {
+		if m.URL == "https://a.example/oldest" {
+			t.Fatal("expected the oldest article to be evicted")
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"container/list"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//how long a cached response is considered fresh and how long after being stored
+//it can still be served (stale) while a background refresh is in flight; both are
+//measured from when the entry was stored. Overridable via Config
+var (
+	cacheFreshTTL = 2 * time.Minute
+	cacheStaleTTL = 10 * time.Minute
+)
+
+//cacheKey identifies one distinct query/language/sort combination. It deliberately
+//excludes page/pageSize: what's cached is the full merged superset for a query,
+//which every page is then sliced from in memory, not a response for one page.
+type cacheKey struct {
+	SearchKey string
+	Language  string
+	SortBy    string
+}
+
+func (k cacheKey) String() string {
+	return fmt.Sprintf("%s|%s|%s", k.SearchKey, k.Language, k.SortBy)
+}
+
+//cacheEntry is what a backend stores per key
+type cacheEntry struct {
+	Results   Results
+	StoredAt  time.Time
+	ExpiresAt time.Time
+}
+
+func (e cacheEntry) fresh() bool {
+	return time.Now().Before(e.ExpiresAt)
+}
+
+func (e cacheEntry) usable() bool {
+	return time.Now().Before(e.StoredAt.Add(cacheStaleTTL))
+}
+
+//CacheBackend is the storage behind resultsCache. The default is an in-memory LRU;
+//a file-backed backend is provided for when results should survive a restart.
+type CacheBackend interface {
+	Get(key string) (cacheEntry, bool)
+	Set(key string, entry cacheEntry)
+}
+
+//resultsCache adds stale-while-revalidate semantics on top of a CacheBackend and
+//tracks hit/miss counters for the admin endpoint
+type resultsCache struct {
+	backend CacheBackend
+	hits    uint64
+	misses  uint64
+
+	mu         sync.Mutex
+	refreshing map[string]bool
+}
+
+func newResultsCache(backend CacheBackend) *resultsCache {
+	return &resultsCache{
+		backend:    backend,
+		refreshing: map[string]bool{},
+	}
+}
+
+//get returns the cached entry for key plus whether it's still fresh enough to
+//skip a refresh entirely
+func (c *resultsCache) get(key cacheKey) (cacheEntry, bool, bool) {
+	entry, ok := c.backend.Get(key.String())
+	if !ok || !entry.usable() {
+		atomic.AddUint64(&c.misses, 1)
+		return cacheEntry{}, false, false
+	}
+
+	atomic.AddUint64(&c.hits, 1)
+	return entry, true, entry.fresh()
+}
+
+func (c *resultsCache) set(key cacheKey, results Results) {
+	c.backend.Set(key.String(), cacheEntry{
+		Results:   results,
+		StoredAt:  time.Now(),
+		ExpiresAt: time.Now().Add(cacheFreshTTL),
+	})
+}
+
+//refreshOnce makes sure only one background refresh per key is in flight at a time
+func (c *resultsCache) refreshOnce(key cacheKey, fetch func() (Results, error)) {
+	k := key.String()
+
+	c.mu.Lock()
+	if c.refreshing[k] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[k] = true
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, k)
+			c.mu.Unlock()
+		}()
+
+		results, err := fetch()
+		if err != nil {
+			log.Printf("cache: background refresh of %q failed: %v", k, err)
+			return
+		}
+		c.set(key, results)
+	}()
+}
+
+func (c *resultsCache) stats() (hits, misses uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
+
+//isRefreshing reports whether a background refresh for key is currently in
+//flight. Used by tests that need to wait for refreshOnce's goroutine to
+//finish without racing on the unexported refreshing map.
+func (c *resultsCache) isRefreshing(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.refreshing[key]
+}
+
+//memoryCacheBackend is a simple in-memory LRU, capped at maxEntries
+type memoryCacheBackend struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	order      *list.List
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry cacheEntry
+}
+
+func newMemoryCacheBackend(maxEntries int) *memoryCacheBackend {
+	return &memoryCacheBackend{
+		maxEntries: maxEntries,
+		items:      map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+func (b *memoryCacheBackend) Get(key string) (cacheEntry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	el, ok := b.items[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	b.order.MoveToFront(el)
+	return el.Value.(*memoryCacheItem).entry, true
+}
+
+func (b *memoryCacheBackend) Set(key string, entry cacheEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if el, ok := b.items[key]; ok {
+		el.Value.(*memoryCacheItem).entry = entry
+		b.order.MoveToFront(el)
+		return
+	}
+
+	el := b.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+	b.items[key] = el
+
+	if b.order.Len() > b.maxEntries {
+		oldest := b.order.Back()
+		if oldest != nil {
+			b.order.Remove(oldest)
+			delete(b.items, oldest.Value.(*memoryCacheItem).key)
+		}
+	}
+}
+
+//fileCacheBackend persists entries to a single gob-encoded file so the cache
+//survives a restart; it trades away LRU eviction for simplicity
+type fileCacheBackend struct {
+	mu   sync.Mutex
+	path string
+	data map[string]cacheEntry
+}
+
+func newFileCacheBackend(path string) (*fileCacheBackend, error) {
+	b := &fileCacheBackend{path: path, data: map[string]cacheEntry{}}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := gob.NewDecoder(f).Decode(&b.data); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *fileCacheBackend) Get(key string) (cacheEntry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := b.data[key]
+	return entry, ok
+}
+
+func (b *fileCacheBackend) Set(key string, entry cacheEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data[key] = entry
+	if err := b.flush(); err != nil {
+		log.Printf("cache: failed to persist to %s: %v", b.path, err)
+	}
+}
+
+func (b *fileCacheBackend) flush() error {
+	f, err := os.Create(b.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gob.NewEncoder(f).Encode(b.data)
+}
+
+//newCacheBackend builds the configured CacheBackend. "memory" (the default) is an
+//in-memory LRU; "file" persists to a gob file so the cache survives a restart.
+func newCacheBackend(kind, path string) (CacheBackend, error) {
+	switch kind {
+	case "", "memory":
+		return newMemoryCacheBackend(1000), nil
+	case "file":
+		return newFileCacheBackend(path)
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", kind)
+	}
+}
+
+//adminCacheStatsHandler exposes hit/miss counters for operators
+func adminCacheStatsHandler(cache *resultsCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		hits, misses := cache.stats()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]uint64{
+			"hits":   hits,
+			"misses": misses,
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResultsCacheFreshHitSkipsRefresh(t *testing.T) {
+	cacheFreshTTL = time.Minute
+	cacheStaleTTL = 10 * time.Minute
+
+	cache := newResultsCache(newMemoryCacheBackend(10))
+	key := cacheKey{SearchKey: "golang"}
+	cache.set(key, Results{TotalResults: 1})
+
+	entry, found, fresh := cache.get(key)
+	if !found || !fresh {
+		t.Fatalf("expected a fresh hit, got found=%v fresh=%v", found, fresh)
+	}
+	if entry.Results.TotalResults != 1 {
+		t.Fatalf("expected cached results back, got %+v", entry.Results)
+	}
+
+	hits, misses := cache.stats()
+	if hits != 1 || misses != 0 {
+		t.Fatalf("expected 1 hit/0 misses, got %d/%d", hits, misses)
+	}
+}
+
+func TestResultsCacheMissWhenEmpty(t *testing.T) {
+	cache := newResultsCache(newMemoryCacheBackend(10))
+
+	_, found, _ := cache.get(cacheKey{SearchKey: "nothing-cached"})
+	if found {
+		t.Fatal("expected a miss on an empty cache")
+	}
+
+	hits, misses := cache.stats()
+	if misses != 1 || hits != 0 {
+		t.Fatalf("expected 0 hits/1 miss, got %d/%d", hits, misses)
+	}
+}
+
+func TestResultsCacheStaleStillUsableButNotFresh(t *testing.T) {
+	cacheFreshTTL = -time.Minute // already expired
+	cacheStaleTTL = time.Minute  // still within the stale window
+	defer func() {
+		cacheFreshTTL = 2 * time.Minute
+		cacheStaleTTL = 10 * time.Minute
+	}()
+
+	cache := newResultsCache(newMemoryCacheBackend(10))
+	key := cacheKey{SearchKey: "golang"}
+	cache.set(key, Results{TotalResults: 1})
+
+	_, found, fresh := cache.get(key)
+	if !found {
+		t.Fatal("expected the stale entry to still be found")
+	}
+	if fresh {
+		t.Fatal("expected the entry to be reported as stale, not fresh")
+	}
+}
+
+func TestRefreshOnceDedupesConcurrentRefreshes(t *testing.T) {
+	cache := newResultsCache(newMemoryCacheBackend(10))
+	key := cacheKey{SearchKey: "golang"}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	calls := 0
+
+	fetch := func() (Results, error) {
+		calls++
+		close(started)
+		<-release
+		return Results{TotalResults: 1}, nil
+	}
+
+	cache.refreshOnce(key, fetch)
+	<-started
+
+	// a second refresh while the first is still in flight must be a no-op
+	cache.refreshOnce(key, func() (Results, error) {
+		t.Fatal("second refresh should not run while one is in flight")
+		return Results{}, nil
+	})
+
+	close(release)
+
+	// give the background goroutine a moment to finish and clear refreshing[key]
+	for i := 0; i < 100 && cache.isRefreshing(key.String()); i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected exactly 1 fetch call, got %d", calls)
+	}
+}
+
+func TestRefreshOnceLeavesCacheUntouchedOnError(t *testing.T) {
+	cache := newResultsCache(newMemoryCacheBackend(10))
+	key := cacheKey{SearchKey: "golang"}
+
+	done := make(chan struct{})
+	cache.refreshOnce(key, func() (Results, error) {
+		defer close(done)
+		return Results{}, errors.New("upstream down")
+	})
+	<-done
+
+	for i := 0; i < 100 && cache.isRefreshing(key.String()); i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, found, _ := cache.get(key); found {
+		t.Fatal("expected no cache entry after a failed refresh")
+	}
+}
+
+func TestMemoryCacheBackendEvictsOldest(t *testing.T) {
+	backend := newMemoryCacheBackend(2)
+
+	backend.Set("a", cacheEntry{Results: Results{TotalResults: 1}})
+	backend.Set("b", cacheEntry{Results: Results{TotalResults: 2}})
+	backend.Set("c", cacheEntry{Results: Results{TotalResults: 3}})
+
+	if _, ok := backend.Get("a"); ok {
+		t.Fatal("expected the oldest entry to be evicted")
+	}
+	if _, ok := backend.Get("b"); !ok {
+		t.Fatal("expected b to still be present")
+	}
+	if _, ok := backend.Get("c"); !ok {
+		t.Fatal("expected c to still be present")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+//Config replaces the old lone -apikey flag: it covers provider credentials,
+//where to listen, cache tuning, which providers are on, and search defaults
+type Config struct {
+	ListenAddr string `json:"listenAddr"`
+
+	APIKeys struct {
+		NewsAPI    string `json:"newsapi"`
+		Bing       string `json:"bing"`
+		Mediastack string `json:"mediastack"`
+	} `json:"apiKeys"`
+
+	//EnabledProviders is the provider name allowlist (e.g. "newsapi", "bing",
+	//"mediastack", "gdelt", "google-news-rss"). Empty means "every provider that
+	//has the credentials it needs".
+	EnabledProviders []string `json:"enabledProviders"`
+
+	Cache struct {
+		Backend  string `json:"backend"`
+		File     string `json:"file"`
+		FreshTTL string `json:"freshTTL"`
+		StaleTTL string `json:"staleTTL"`
+	} `json:"cache"`
+
+	Archive struct {
+		File    string `json:"file"`
+		MaxDocs int    `json:"maxDocs"`
+	} `json:"archive"`
+
+	Subscriptions struct {
+		File string `json:"file"`
+	} `json:"subscriptions"`
+
+	UserAgentRefresh string `json:"userAgentRefresh"`
+
+	DefaultLanguage string `json:"defaultLanguage"`
+	DefaultSortBy   string `json:"defaultSortBy"`
+
+	RateLimit struct {
+		RequestsPerMinute int `json:"requestsPerMinute"`
+		Burst             int `json:"burst"`
+	} `json:"rateLimit"`
+}
+
+//defaultConfig mirrors the flag defaults the config.json file is replacing
+func defaultConfig() Config {
+	cfg := Config{
+		ListenAddr:      ":3000",
+		DefaultLanguage: "en",
+		DefaultSortBy:   "publishedAt",
+	}
+	cfg.Cache.Backend = "memory"
+	cfg.Cache.File = "cache.gob"
+	cfg.Cache.FreshTTL = "2m"
+	cfg.Cache.StaleTTL = "10m"
+	cfg.Archive.File = "archive.gob"
+	cfg.Archive.MaxDocs = defaultMaxArchiveDocs
+	cfg.Subscriptions.File = "subscriptions.json"
+	cfg.UserAgentRefresh = "24h"
+	cfg.RateLimit.RequestsPerMinute = 60
+	cfg.RateLimit.Burst = 10
+	return cfg
+}
+
+//loadConfig reads config.json at path, falling back to defaultConfig for any
+//field the file doesn't set
+func loadConfig(path string) (Config, error) {
+	cfg := defaultConfig()
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func (c Config) cacheFreshTTL() time.Duration {
+	return parseDurationOr(c.Cache.FreshTTL, cacheFreshTTL)
+}
+
+func (c Config) cacheStaleTTL() time.Duration {
+	return parseDurationOr(c.Cache.StaleTTL, cacheStaleTTL)
+}
+
+func (c Config) userAgentRefreshInterval() time.Duration {
+	return parseDurationOr(c.UserAgentRefresh, 24*time.Hour)
+}
+
+func parseDurationOr(value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fallback
+	}
+	return d
+}
+
+//providerEnabled reports whether name is allowed to run under cfg.EnabledProviders.
+//An empty allowlist means every provider is allowed.
+func (c Config) providerEnabled(name string) bool {
+	if len(c.EnabledProviders) == 0 {
+		return true
+	}
+	for _, p := range c.EnabledProviders {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigMissingFileFallsBackToDefaults(t *testing.T) {
+	cfg, err := loadConfig(filepath.Join(t.TempDir(), "does-not-exist.json"))
+	if err != nil {
+		t.Fatalf("expected a missing config file to be ok, got %v", err)
+	}
+	if cfg.ListenAddr != ":3000" {
+		t.Fatalf("expected default ListenAddr, got %q", cfg.ListenAddr)
+	}
+	if cfg.Archive.MaxDocs != defaultMaxArchiveDocs {
+		t.Fatalf("expected default Archive.MaxDocs, got %d", cfg.Archive.MaxDocs)
+	}
+	if cfg.Subscriptions.File != "subscriptions.json" {
+		t.Fatalf("expected default Subscriptions.File, got %q", cfg.Subscriptions.File)
+	}
+}
+
+func TestLoadConfigOverridesOnlySetFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"listenAddr": ":9000"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ListenAddr != ":9000" {
+		t.Fatalf("expected overridden ListenAddr, got %q", cfg.ListenAddr)
+	}
+	//everything not present in the file should still carry its default
+	if cfg.DefaultSortBy != "publishedAt" {
+		t.Fatalf("expected default DefaultSortBy to survive, got %q", cfg.DefaultSortBy)
+	}
+}
+
+func TestParseDurationOrFallsBackOnEmptyOrInvalid(t *testing.T) {
+	fallback := 42 * time.Second
+
+	if got := parseDurationOr("", fallback); got != fallback {
+		t.Fatalf("expected fallback for empty string, got %v", got)
+	}
+	if got := parseDurationOr("not-a-duration", fallback); got != fallback {
+		t.Fatalf("expected fallback for invalid duration, got %v", got)
+	}
+	if got := parseDurationOr("5s", fallback); got != 5*time.Second {
+		t.Fatalf("expected parsed duration, got %v", got)
+	}
+}
+
+func TestProviderEnabledEmptyAllowlistAllowsEverything(t *testing.T) {
+	cfg := Config{}
+	if !cfg.providerEnabled("newsapi") {
+		t.Fatal("expected an empty allowlist to allow every provider")
+	}
+}
+
+func TestProviderEnabledRespectsAllowlist(t *testing.T) {
+	cfg := Config{EnabledProviders: []string{"gdelt"}}
+	if !cfg.providerEnabled("gdelt") {
+		t.Fatal("expected gdelt to be allowed")
+	}
+	if cfg.providerEnabled("bing") {
+		t.Fatal("expected bing to be disallowed")
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"html/template"
@@ -81,20 +80,43 @@ func (s *Search) PreviousPage() int {
 
 var tpl = template.Must(template.ParseFiles("index.html"))
 
-var apiKey *string
+var cfg Config
+var cache *resultsCache
 
 func indexHandler(w http.ResponseWriter, r *http.Request) {
 	tpl.Execute(w, nil)
 }
 
-//function to hanlde search
-func searchHandler(w http.ResponseWriter, r *http.Request) {
+//enabledProviders builds the list of providers allowed by cfg.EnabledProviders that
+//also have the credentials they need. GDELT and the Google News RSS feed need no key.
+func enabledProviders() []NewsProvider {
+	var providers []NewsProvider
+
+	if cfg.providerEnabled("gdelt") {
+		providers = append(providers, &GDELTProvider{})
+	}
+	if cfg.providerEnabled("google-news-rss") {
+		providers = append(providers, &GoogleNewsRSSProvider{})
+	}
+	if cfg.providerEnabled("newsapi") && cfg.APIKeys.NewsAPI != "" {
+		providers = append(providers, &NewsAPIProvider{APIKey: cfg.APIKeys.NewsAPI})
+	}
+	if cfg.providerEnabled("bing") && cfg.APIKeys.Bing != "" {
+		providers = append(providers, &BingNewsProvider{APIKey: cfg.APIKeys.Bing})
+	}
+	if cfg.providerEnabled("mediastack") && cfg.APIKeys.Mediastack != "" {
+		providers = append(providers, &MediastackProvider{APIKey: cfg.APIKeys.Mediastack})
+	}
 
+	return providers
+}
+
+//doSearch parses the request, serves the merged provider results (from cache where
+//possible) and builds the Search the HTML and JSON handlers both render from
+func doSearch(r *http.Request) (*Search, *apiError) {
 	u, err := url.Parse(r.URL.String())
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Internal server error"))
-		return
+		return nil, newAPIError(http.StatusInternalServerError, "bad_request", "Internal server error")
 	}
 
 	//gets search parameter
@@ -110,67 +132,126 @@ func searchHandler(w http.ResponseWriter, r *http.Request) {
 
 	next, err := strconv.Atoi(page)
 	if err != nil {
-		http.Error(w, "Unexpected server error", http.StatusInternalServerError)
-		return
+		return nil, newAPIError(http.StatusInternalServerError, "internal_error", "Unexpected server error")
 	}
 
 	search.NextPage = next
-	pageSize := 20
-
-	//comsume newsapi endpoint with parameter
-	endpoint := fmt.Sprintf("https://newsapi.org/v2/everything?q=%s&pageSize=%d&page=%d&apiKey=%s&sortBy=publishedAt&language=en", url.QueryEscape(search.SearchKey), pageSize, search.NextPage, *apiKey)
-	resp, err := http.Get(endpoint)
 
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Internal server error"))
-		return
+	if search.NextPage < 1 {
+		return nil, newAPIError(http.StatusBadRequest, "invalid_page", "page must be a positive integer")
 	}
 
-	defer resp.Body.Close()
+	pageSize := 20
 
-	if resp.StatusCode != 200 {
-		newError := &NewsAPIError{}
-		err := json.NewDecoder(resp.Body).Decode(newError)
-		if err != nil {
-			http.Error(w, "Unexpected server error", http.StatusInternalServerError)
-			return
+	//the cache (and the providers) are queried once per search key, not once per
+	//page: GDELT and the Google News RSS feed ignore paging entirely, so asking
+	//them again per page would return a different small batch each time instead
+	//of a stable superset to paginate over
+	key := cacheKey{SearchKey: search.SearchKey, Language: cfg.DefaultLanguage, SortBy: cfg.DefaultSortBy}
+
+	//fetch is what runs on an actual cache miss/refresh: it's the only place new
+	//Articles enter the process, so it's also the only place that needs to index
+	//them into the archive - a cache hit (fresh or stale-served) is the exact same
+	//Articles already indexed, and indexing it again would just pay a full-archive
+	//disk flush for every repeat search or page click
+	fetch := func() (Results, error) {
+		all := fetchAll(enabledProviders(), search.SearchKey, 1, supersetSize)
+		if len(all) == 0 {
+			return Results{}, fmt.Errorf("no provider returned results")
 		}
-
-		http.Error(w, newError.Message, http.StatusInternalServerError)
-		return
+		merged := mergeResults(all)
+		archive.index(merged.Articles)
+		return merged, nil
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&search.Results)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+	var merged Results
+
+	entry, found, fresh := cache.get(key)
+	switch {
+	case found && fresh:
+		merged = entry.Results
+	case found:
+		//stale but still usable: serve it and kick off a background refresh
+		merged = entry.Results
+		cache.refreshOnce(key, fetch)
+	default:
+		results, err := fetch()
+		if err != nil {
+			return nil, newAPIError(http.StatusInternalServerError, "internal_error", "Unexpected server error")
+		}
+		cache.set(key, results)
+		merged = results
 	}
 
-	search.TotalPages = int(math.Ceil(float64(search.Results.TotalResults / pageSize)))
+	search.Results = paginate(merged, search.NextPage, pageSize)
+
+	search.TotalPages = int(math.Ceil(float64(merged.TotalResults) / float64(pageSize)))
 
 	if ok := !search.IsLastPage(); ok {
 		search.NextPage++
 	}
 
-	err = tpl.Execute(w, search)
-	if err != nil {
+	return search, nil
+}
+
+//function to hanlde search, rendering either the HTML template or a JSON
+//response depending on the request's path/Accept header
+func searchHandler(w http.ResponseWriter, r *http.Request) {
+	search, apiErr := doSearch(r)
+
+	if wantsJSON(r) {
+		if apiErr != nil {
+			writeJSONError(w, apiErr)
+			return
+		}
+		writeJSONSearch(w, search)
+		return
+	}
+
+	if apiErr != nil {
+		http.Error(w, apiErr.Message, apiErr.httpStatus)
+		return
+	}
+
+	if err := tpl.Execute(w, search); err != nil {
 		log.Println(err)
 	}
 }
 
 func main() {
-	//pass in apikey as a flag for application to run, else just crash
-	apiKey = flag.String("apikey", "", "Newsapi.org access key")
+	configPath := flag.String("config", "config.json", "Path to the config.json file")
 	flag.Parse()
 
-	if *apiKey == "" {
-		log.Fatal("apiKey must be set")
+	loaded, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg = loaded
+
+	backend, err := newCacheBackend(cfg.Cache.Backend, cfg.Cache.File)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cache = newResultsCache(backend)
+	cacheFreshTTL = cfg.cacheFreshTTL()
+	cacheStaleTTL = cfg.cacheStaleTTL()
+
+	archive, err = newArticleArchive(cfg.Archive.File, cfg.Archive.MaxDocs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	subscriptions, err = newSubscriptionsStore(cfg.Subscriptions.File)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
+	httpClient.Transport = &userAgentTransport{wrapped: http.DefaultTransport}
+	startUserAgentRefresher(cfg.userAgentRefreshInterval())
+
+	listenAddr := cfg.ListenAddr
+	if port := os.Getenv("PORT"); port != "" {
+		listenAddr = ":" + port
 	}
 
 	mux := http.NewServeMux()
@@ -180,6 +261,24 @@ func main() {
 
 	//routes
 	mux.HandleFunc("/search", searchHandler)
+	mux.HandleFunc("/api/v1/search", searchHandler)
 	mux.HandleFunc("/", indexHandler)
-	http.ListenAndServe(":"+port, mux)
+	mux.HandleFunc("/admin/cache/stats", adminCacheStatsHandler(cache))
+	mux.HandleFunc("/opml/export", opmlExportHandler)
+	mux.HandleFunc("/opml/import", opmlImportHandler)
+	mux.HandleFunc("/saved-searches", addSavedSearchHandler)
+	mux.HandleFunc("/followed-sources", addFollowedSourceHandler)
+	mux.HandleFunc("/archive", archiveHandler)
+	mux.HandleFunc("/archive/sources", archiveSourcesHandler)
+
+	limiter := newRateLimiter(cfg.RateLimit.RequestsPerMinute, cfg.RateLimit.Burst)
+
+	//layered middleware chain: logging outermost, recover innermost. recoverMiddleware
+	//has to sit closer to mux than gzipMiddleware, not further out - otherwise a panic
+	//unwinds through gzip's deferred gz.Close() (flushing a compressed response) before
+	//recover gets to write its error, corrupting the response. With recover innermost,
+	//its http.Error call goes through the same gzip writer gzipMiddleware already set up.
+	handler := chain(mux, loggingMiddleware, gzipMiddleware, limiter.middleware, noStoreMiddleware, recoverMiddleware)
+
+	http.ListenAndServe(listenAddr, handler)
 }
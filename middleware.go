@@ -0,0 +1,171 @@
+package main
+
+import (
+	"compress/gzip"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//middleware matches the layered apiHandler = xMiddleware(apiHandler) style:
+//each one wraps a handler and returns a handler
+type middleware func(http.Handler) http.Handler
+
+func chain(h http.Handler, mws ...middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+//statusRecorder captures the status code a handler wrote, since http.ResponseWriter
+//doesn't expose it
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+//loggingMiddleware logs method, path, status and latency for every request
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start))
+	})
+}
+
+//recoverMiddleware turns a panic in any downstream handler into a 500 instead of
+//taking the whole server down
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Printf("panic handling %s: %v", r.URL.Path, err)
+				http.Error(w, "Unexpected server error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+//noStoreMiddleware keeps API responses out of caches; the app has its own
+//results cache and doesn't want the browser or a proxy second-guessing it
+func noStoreMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	writer *gzip.Writer
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	return w.writer.Write(b)
+}
+
+//gzipMiddleware compresses the response when the client advertises support for it
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+
+		next.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, writer: gz}, r)
+	})
+}
+
+//tokenBucket is a simple per-client rate limiter
+type tokenBucket struct {
+	tokens     float64
+	maxTokens  float64
+	refillRate float64 //tokens per second
+	lastRefill time.Time
+}
+
+func (b *tokenBucket) allow() bool {
+	now := time.Now()
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.lastRefill = now
+
+	b.tokens += elapsed * b.refillRate
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+//rateLimiter hands out a token bucket per client IP
+type rateLimiter struct {
+	mu                sync.Mutex
+	buckets           map[string]*tokenBucket
+	requestsPerMinute int
+	burst             int
+}
+
+func newRateLimiter(requestsPerMinute, burst int) *rateLimiter {
+	return &rateLimiter{
+		buckets:           map[string]*tokenBucket{},
+		requestsPerMinute: requestsPerMinute,
+		burst:             burst,
+	}
+}
+
+func (rl *rateLimiter) allow(clientIP string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	b, ok := rl.buckets[clientIP]
+	if !ok {
+		b = &tokenBucket{
+			tokens:     float64(rl.burst),
+			maxTokens:  float64(rl.burst),
+			refillRate: float64(rl.requestsPerMinute) / 60,
+			lastRefill: time.Now(),
+		}
+		rl.buckets[clientIP] = b
+	}
+	return b.allow()
+}
+
+//middleware wraps next with a token-bucket rate limiter keyed by client IP
+func (rl *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		if !rl.allow(host) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketAllowsUpToBurstThenBlocks(t *testing.T) {
+	b := &tokenBucket{tokens: 3, maxTokens: 3, refillRate: 0, lastRefill: time.Now()}
+
+	for i := 0; i < 3; i++ {
+		if !b.allow() {
+			t.Fatalf("expected request %d within burst to be allowed", i+1)
+		}
+	}
+	if b.allow() {
+		t.Fatal("expected the request past the burst to be denied")
+	}
+}
+
+func TestTokenBucketRefillsOverTime(t *testing.T) {
+	b := &tokenBucket{tokens: 0, maxTokens: 5, refillRate: 10, lastRefill: time.Now().Add(-time.Second)}
+
+	if !b.allow() {
+		t.Fatal("expected a token to have refilled after a second at 10/s")
+	}
+}
+
+func TestTokenBucketCapsAtMaxTokens(t *testing.T) {
+	b := &tokenBucket{tokens: 0, maxTokens: 2, refillRate: 100, lastRefill: time.Now().Add(-time.Minute)}
+
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if b.allow() {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Fatalf("expected tokens to cap at maxTokens=2, got %d allowed calls", allowed)
+	}
+}
+
+func TestRateLimiterTracksBucketsPerClient(t *testing.T) {
+	rl := newRateLimiter(60, 1)
+
+	if !rl.allow("1.1.1.1") {
+		t.Fatal("expected the first request from a client to be allowed")
+	}
+	if rl.allow("1.1.1.1") {
+		t.Fatal("expected the second immediate request from the same client to be denied")
+	}
+	if !rl.allow("2.2.2.2") {
+		t.Fatal("expected a different client to have its own bucket")
+	}
+}
+
+//a panic must be caught before gzipMiddleware's deferred gz.Close() flushes a
+//compressed response, or the client is left with a corrupt, mislabeled body:
+//recoverMiddleware has to sit closer to the handler than gzipMiddleware, not
+//further out.
+func TestRecoverMiddlewareInsideGzipProducesValidResponse(t *testing.T) {
+	panicHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	handler := chain(panicHandler, gzipMiddleware, recoverMiddleware)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected Content-Encoding: gzip, got %q", rec.Header().Get("Content-Encoding"))
+	}
+
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("response body is not valid gzip: %v", err)
+	}
+	defer gz.Close()
+
+	body, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("failed to decompress response body: %v", err)
+	}
+	if string(body) != "Unexpected server error\n" {
+		t.Fatalf("unexpected decompressed body: %q", body)
+	}
+}
+
+func TestRecoverMiddlewareWithoutGzipStillRecovers(t *testing.T) {
+	panicHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	handler := chain(panicHandler, recoverMiddleware)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+	if rec.Body.String() != "Unexpected server error\n" {
+		t.Fatalf("unexpected body: %q", rec.Body.String())
+	}
+}
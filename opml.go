@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+//SavedSearch is a query a user wants to revisit
+type SavedSearch struct {
+	Query string
+}
+
+//FollowedSource is a news source a user wants to keep in their OPML exports.
+//It is its own type holding just a name and a feed URL; it does not embed or
+//reference the Source struct used for Article.Source
+type FollowedSource struct {
+	Name    string
+	FeedURL string
+}
+
+//subscriptionsData is what gets persisted to disk as JSON, matching the plain
+//struct persistence already used by the file cache backend
+type subscriptionsData struct {
+	SavedSearches   []SavedSearch
+	FollowedSources []FollowedSource
+}
+
+//subscriptionsStore is the store backing the OPML import/export handlers and the
+//saved-search/followed-source handlers; it persists to path on every mutation so
+//it survives a restart
+type subscriptionsStore struct {
+	mu   sync.Mutex
+	path string
+	data subscriptionsData
+}
+
+//newSubscriptionsStore loads any previously persisted subscriptions from path (if
+//it exists). Pass an empty path to keep the store in-memory only, e.g. in tests.
+func newSubscriptionsStore(path string) (*subscriptionsStore, error) {
+	s := &subscriptionsStore{path: path}
+
+	if path == "" {
+		return s, nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&s.data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *subscriptionsStore) addSavedSearch(query string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.SavedSearches = append(s.data.SavedSearches, SavedSearch{Query: query})
+	return s.flushLocked()
+}
+
+func (s *subscriptionsStore) addFollowedSource(name, feedURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.FollowedSources = append(s.data.FollowedSources, FollowedSource{Name: name, FeedURL: feedURL})
+	return s.flushLocked()
+}
+
+func (s *subscriptionsStore) snapshot() ([]SavedSearch, []FollowedSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SavedSearch(nil), s.data.SavedSearches...), append([]FollowedSource(nil), s.data.FollowedSources...)
+}
+
+//flushLocked persists the store to disk; caller must hold s.mu. A zero-value
+//path (in-memory only store) is a no-op.
+func (s *subscriptionsStore) flushLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	f, err := os.Create(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(s.data)
+}
+
+//subscriptions is initialized in main() once cfg is loaded
+var subscriptions *subscriptionsStore
+
+//opml* types model just enough of the OPML 2.0 spec for blogroll-style outlines
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title string `xml:"title"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlOutline struct {
+	Text   string `xml:"text,attr"`
+	Title  string `xml:"title,attr"`
+	XMLURL string `xml:"xmlUrl,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+func buildOPML(searches []SavedSearch, sources []FollowedSource) opmlDocument {
+	doc := opmlDocument{
+		Version: "2.0",
+		Head:    opmlHead{Title: "newsapp subscriptions"},
+	}
+	for _, s := range searches {
+		doc.Body.Outlines = append(doc.Body.Outlines, opmlOutline{
+			Text:   s.Query,
+			Title:  s.Query,
+			XMLURL: "/search?q=" + url.QueryEscape(s.Query),
+			Type:   "rss",
+		})
+	}
+	for _, s := range sources {
+		doc.Body.Outlines = append(doc.Body.Outlines, opmlOutline{
+			Text:   s.Name,
+			Title:  s.Name,
+			XMLURL: s.FeedURL,
+			Type:   "rss",
+		})
+	}
+	return doc
+}
+
+//opmlExportHandler returns saved searches and followed sources as an OPML 2.0 document
+func opmlExportHandler(w http.ResponseWriter, r *http.Request) {
+	searches, sources := subscriptions.snapshot()
+	doc := buildOPML(searches, sources)
+
+	w.Header().Set("Content-Type", "text/x-opml+xml")
+	w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(doc); err != nil {
+		log.Println(err)
+	}
+}
+
+//opmlImportHandler accepts a multipart-uploaded OPML document and merges its
+//outlines into the saved searches and followed sources stores
+func opmlImportHandler(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("opml")
+	if err != nil {
+		http.Error(w, "Unexpected server error", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	var doc opmlDocument
+	if err := xml.NewDecoder(file).Decode(&doc); err != nil {
+		http.Error(w, "Unexpected server error", http.StatusBadRequest)
+		return
+	}
+
+	for _, outline := range doc.Body.Outlines {
+		if strings.HasPrefix(outline.XMLURL, "/search?q=") {
+			if u, err := url.Parse(outline.XMLURL); err == nil {
+				subscriptions.addSavedSearch(u.Query().Get("q"))
+				continue
+			}
+		}
+		subscriptions.addFollowedSource(outline.Title, outline.XMLURL)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//addSavedSearchHandler lets a user save a search from the app itself, not just
+//via OPML import, so opmlExportHandler isn't a dead loop with nothing feeding it
+func addSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Unexpected server error", http.StatusBadRequest)
+		return
+	}
+
+	query := r.FormValue("q")
+	if query == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := subscriptions.addSavedSearch(query); err != nil {
+		log.Println(err)
+		http.Error(w, "Unexpected server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//addFollowedSourceHandler lets a user follow a source from the app itself
+func addFollowedSourceHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Unexpected server error", http.StatusBadRequest)
+		return
+	}
+
+	name := r.FormValue("name")
+	feedURL := r.FormValue("feedUrl")
+	if name == "" || feedURL == "" {
+		http.Error(w, "name and feedUrl are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := subscriptions.addFollowedSource(name, feedURL); err != nil {
+		log.Println(err)
+		http.Error(w, "Unexpected server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
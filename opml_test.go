@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildOPMLIncludesSearchesAndSources(t *testing.T) {
+	doc := buildOPML(
+		[]SavedSearch{{Query: "golang"}},
+		[]FollowedSource{{Name: "BBC", FeedURL: "https://bbc.example/rss"}},
+	)
+
+	if len(doc.Body.Outlines) != 2 {
+		t.Fatalf("expected 2 outlines, got %d", len(doc.Body.Outlines))
+	}
+	if doc.Body.Outlines[0].XMLURL != "/search?q="+url.QueryEscape("golang") {
+		t.Fatalf("unexpected saved search outline URL: %q", doc.Body.Outlines[0].XMLURL)
+	}
+	if doc.Body.Outlines[1].XMLURL != "https://bbc.example/rss" {
+		t.Fatalf("unexpected followed source outline URL: %q", doc.Body.Outlines[1].XMLURL)
+	}
+}
+
+func TestSubscriptionsStoreRoundTripsToDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+
+	store, err := newSubscriptionsStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.addSavedSearch("golang"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.addFollowedSource("BBC", "https://bbc.example/rss"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := newSubscriptionsStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	searches, sources := reloaded.snapshot()
+	if len(searches) != 1 || searches[0].Query != "golang" {
+		t.Fatalf("expected the saved search to survive a reload, got %+v", searches)
+	}
+	if len(sources) != 1 || sources[0].Name != "BBC" {
+		t.Fatalf("expected the followed source to survive a reload, got %+v", sources)
+	}
+}
+
+func TestOPMLExportReflectsWhatWasAdded(t *testing.T) {
+	store, err := newSubscriptionsStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := subscriptions
+	subscriptions = store
+	defer func() { subscriptions = old }()
+
+	if err := subscriptions.addSavedSearch("golang"); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/opml/export", nil)
+	rec := httptest.NewRecorder()
+	opmlExportHandler(rec, req)
+
+	var doc opmlDocument
+	body := strings.TrimPrefix(rec.Body.String(), xml.Header)
+	if err := xml.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("failed to parse exported OPML: %v", err)
+	}
+	if len(doc.Body.Outlines) != 1 || doc.Body.Outlines[0].Text != "golang" {
+		t.Fatalf("expected the added search to show up in the export, got %+v", doc.Body.Outlines)
+	}
+}
+
+func TestAddSavedSearchHandlerPersistsAndRejectsEmpty(t *testing.T) {
+	store, err := newSubscriptionsStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := subscriptions
+	subscriptions = store
+	defer func() { subscriptions = old }()
+
+	form := url.Values{"q": {"golang"}}
+	req := httptest.NewRequest(http.MethodPost, "/saved-searches", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	addSavedSearchHandler(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	searches, _ := subscriptions.snapshot()
+	if len(searches) != 1 || searches[0].Query != "golang" {
+		t.Fatalf("expected the search to be saved, got %+v", searches)
+	}
+
+	emptyReq := httptest.NewRequest(http.MethodPost, "/saved-searches", strings.NewReader(url.Values{}.Encode()))
+	emptyReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	emptyRec := httptest.NewRecorder()
+	addSavedSearchHandler(emptyRec, emptyReq)
+
+	if emptyRec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a missing q, got %d", emptyRec.Code)
+	}
+}
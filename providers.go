@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//how long we allow a single provider to answer before we give up on it
+const providerTimeout = 8 * time.Second
+
+//supersetSize is how many articles we ask each provider for on every fetch.
+//GDELT and the Google News RSS feed don't support real paging, so instead of
+//re-querying providers per page (and getting a different small batch each
+//time), we fetch one superset per query and paginate it in memory.
+const supersetSize = 100
+
+//NewsProvider is implemented by every upstream source searchHandler can fan out to
+type NewsProvider interface {
+	Name() string
+	Fetch(ctx context.Context, query string, page, pageSize int) (Results, error)
+}
+
+//NewsAPIProvider wraps the original newsapi.org integration
+type NewsAPIProvider struct {
+	APIKey string
+}
+
+func (p *NewsAPIProvider) Name() string { return "newsapi" }
+
+func (p *NewsAPIProvider) Fetch(ctx context.Context, query string, page, pageSize int) (Results, error) {
+	endpoint := fmt.Sprintf("https://newsapi.org/v2/everything?q=%s&pageSize=%d&page=%d&apiKey=%s&sortBy=publishedAt&language=en",
+		url.QueryEscape(query), pageSize, page, p.APIKey)
+
+	resp, err := doGet(ctx, endpoint)
+	if err != nil {
+		return Results{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		newError := &NewsAPIError{}
+		if err := json.NewDecoder(resp.Body).Decode(newError); err != nil {
+			return Results{}, err
+		}
+		return Results{}, fmt.Errorf("newsapi: %s", newError.Message)
+	}
+
+	var results Results
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return Results{}, err
+	}
+	return results, nil
+}
+
+//GDELTProvider queries the GDELT DOC 2.0 API, which needs no key
+type GDELTProvider struct{}
+
+func (p *GDELTProvider) Name() string { return "gdelt" }
+
+type gdeltResponse struct {
+	Articles []struct {
+		URL       string `json:"url"`
+		Title     string `json:"title"`
+		SeenDate  string `json:"seendate"`
+		Domain    string `json:"domain"`
+		SocialImg string `json:"socialimage"`
+	} `json:"articles"`
+}
+
+func (p *GDELTProvider) Fetch(ctx context.Context, query string, page, pageSize int) (Results, error) {
+	endpoint := fmt.Sprintf("https://api.gdeltproject.org/api/v2/doc/doc?query=%s&mode=artlist&maxrecords=%d&format=json",
+		url.QueryEscape(query), pageSize)
+
+	resp, err := doGet(ctx, endpoint)
+	if err != nil {
+		return Results{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return Results{}, fmt.Errorf("gdelt: unexpected status %d", resp.StatusCode)
+	}
+
+	var parsed gdeltResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Results{}, err
+	}
+
+	results := Results{Status: "ok"}
+	for _, a := range parsed.Articles {
+		published, _ := time.Parse("20060102150405", a.SeenDate)
+		results.Articles = append(results.Articles, Article{
+			Source:      Source{Name: a.Domain},
+			Title:       a.Title,
+			URL:         a.URL,
+			URLToImage:  a.SocialImg,
+			PublishedAt: published,
+		})
+	}
+	results.TotalResults = len(results.Articles)
+	return results, nil
+}
+
+//BingNewsProvider queries the Bing News Search API
+type BingNewsProvider struct {
+	APIKey string
+}
+
+func (p *BingNewsProvider) Name() string { return "bing" }
+
+type bingResponse struct {
+	Value []struct {
+		Name          string `json:"name"`
+		URL           string `json:"url"`
+		Description   string `json:"description"`
+		DatePublished string `json:"datePublished"`
+		Image         struct {
+			Thumbnail struct {
+				ContentURL string `json:"contentUrl"`
+			} `json:"thumbnail"`
+		} `json:"image"`
+		Provider []struct {
+			Name string `json:"name"`
+		} `json:"provider"`
+	} `json:"value"`
+}
+
+func (p *BingNewsProvider) Fetch(ctx context.Context, query string, page, pageSize int) (Results, error) {
+	offset := (page - 1) * pageSize
+	endpoint := fmt.Sprintf("https://api.bing.microsoft.com/v7.0/news/search?q=%s&count=%d&offset=%d&sortBy=Date",
+		url.QueryEscape(query), pageSize, offset)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Results{}, err
+	}
+	req.Header.Set("Ocp-Apim-Subscription-Key", p.APIKey)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Results{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return Results{}, fmt.Errorf("bing: unexpected status %d", resp.StatusCode)
+	}
+
+	var parsed bingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Results{}, err
+	}
+
+	results := Results{Status: "ok"}
+	for _, v := range parsed.Value {
+		published, _ := time.Parse(time.RFC3339, v.DatePublished)
+		sourceName := ""
+		if len(v.Provider) > 0 {
+			sourceName = v.Provider[0].Name
+		}
+		results.Articles = append(results.Articles, Article{
+			Source:      Source{Name: sourceName},
+			Title:       v.Name,
+			Description: v.Description,
+			URL:         v.URL,
+			URLToImage:  v.Image.Thumbnail.ContentURL,
+			PublishedAt: published,
+		})
+	}
+	results.TotalResults = len(results.Articles)
+	return results, nil
+}
+
+//GoogleNewsRSSProvider scrapes the public Google News RSS feed, which needs no key
+type GoogleNewsRSSProvider struct{}
+
+func (p *GoogleNewsRSSProvider) Name() string { return "google-news-rss" }
+
+type rssFeed struct {
+	Channel struct {
+		Items []struct {
+			Title   string `xml:"title"`
+			Link    string `xml:"link"`
+			PubDate string `xml:"pubDate"`
+			Source  struct {
+				Name string `xml:",chardata"`
+			} `xml:"source"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+func (p *GoogleNewsRSSProvider) Fetch(ctx context.Context, query string, page, pageSize int) (Results, error) {
+	endpoint := fmt.Sprintf("https://news.google.com/rss/search?q=%s&hl=en-US&gl=US&ceid=US:en", url.QueryEscape(query))
+
+	resp, err := doGet(ctx, endpoint)
+	if err != nil {
+		return Results{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return Results{}, fmt.Errorf("google-news-rss: unexpected status %d", resp.StatusCode)
+	}
+
+	var feed rssFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return Results{}, err
+	}
+
+	results := Results{Status: "ok"}
+	for i, item := range feed.Channel.Items {
+		if i >= pageSize {
+			break
+		}
+		published, _ := time.Parse(time.RFC1123, item.PubDate)
+		results.Articles = append(results.Articles, Article{
+			Source:      Source{Name: item.Source.Name},
+			Title:       item.Title,
+			URL:         item.Link,
+			PublishedAt: published,
+		})
+	}
+	results.TotalResults = len(results.Articles)
+	return results, nil
+}
+
+//MediastackProvider queries the mediastack API
+type MediastackProvider struct {
+	APIKey string
+}
+
+func (p *MediastackProvider) Name() string { return "mediastack" }
+
+type mediastackResponse struct {
+	Data []struct {
+		Author      string `json:"author"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		URL         string `json:"url"`
+		Image       string `json:"image"`
+		Source      string `json:"source"`
+		PublishedAt string `json:"published_at"`
+	} `json:"data"`
+}
+
+func (p *MediastackProvider) Fetch(ctx context.Context, query string, page, pageSize int) (Results, error) {
+	offset := (page - 1) * pageSize
+	endpoint := fmt.Sprintf("http://api.mediastack.com/v1/news?access_key=%s&keywords=%s&limit=%d&offset=%d&sort=published_desc",
+		p.APIKey, url.QueryEscape(query), pageSize, offset)
+
+	resp, err := doGet(ctx, endpoint)
+	if err != nil {
+		return Results{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return Results{}, fmt.Errorf("mediastack: unexpected status %d", resp.StatusCode)
+	}
+
+	var parsed mediastackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Results{}, err
+	}
+
+	results := Results{Status: "ok"}
+	for _, d := range parsed.Data {
+		published, _ := time.Parse(time.RFC3339, d.PublishedAt)
+		results.Articles = append(results.Articles, Article{
+			Source:      Source{Name: d.Source},
+			Author:      d.Author,
+			Title:       d.Title,
+			Description: d.Description,
+			URL:         d.URL,
+			URLToImage:  d.Image,
+			PublishedAt: published,
+		})
+	}
+	results.TotalResults = len(results.Articles)
+	return results, nil
+}
+
+//doGet issues a GET request against httpClient bound to ctx
+func doGet(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	return httpClient.Do(req)
+}
+
+//httpClient is shared by every provider so outbound requests get a consistent transport
+var httpClient = &http.Client{}
+
+//fetchAll fans out to every enabled provider concurrently, each bounded by providerTimeout,
+//and returns whatever results came back without failing the whole search over one bad provider
+func fetchAll(providers []NewsProvider, query string, page, pageSize int) []Results {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results []Results
+	)
+
+	for _, p := range providers {
+		wg.Add(1)
+		go func(p NewsProvider) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+			defer cancel()
+
+			res, err := p.Fetch(ctx, query, page, pageSize)
+			if err != nil {
+				log.Printf("provider %s: %v", p.Name(), err)
+				return
+			}
+
+			mu.Lock()
+			results = append(results, res)
+			mu.Unlock()
+		}(p)
+	}
+
+	wg.Wait()
+	return results
+}
+
+//canonicalTitle normalizes a title for dedup comparison
+func canonicalTitle(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
+
+//mergeResults dedups articles by URL and canonical title across every provider's results,
+//then sorts the merged set by PublishedAt, newest first
+func mergeResults(all []Results) Results {
+	seenURL := map[string]bool{}
+	seenTitle := map[string]bool{}
+
+	merged := Results{Status: "ok"}
+	for _, res := range all {
+		for _, a := range res.Articles {
+			if a.URL != "" && seenURL[a.URL] {
+				continue
+			}
+			title := canonicalTitle(a.Title)
+			if title != "" && seenTitle[title] {
+				continue
+			}
+			if a.URL != "" {
+				seenURL[a.URL] = true
+			}
+			if title != "" {
+				seenTitle[title] = true
+			}
+			merged.Articles = append(merged.Articles, a)
+		}
+	}
+
+	sort.Slice(merged.Articles, func(i, j int) bool {
+		return merged.Articles[i].PublishedAt.After(merged.Articles[j].PublishedAt)
+	})
+
+	merged.TotalResults = len(merged.Articles)
+	return merged
+}
+
+//paginate slices the merged article set to the requested page. Pages are 1-based;
+//anything less than 1 is clamped so a bad page number can't produce a negative slice bound.
+func paginate(results Results, page, pageSize int) Results {
+	if page < 1 {
+		page = 1
+	}
+
+	start := (page - 1) * pageSize
+	if start >= len(results.Articles) {
+		return Results{Status: results.Status, TotalResults: results.TotalResults}
+	}
+
+	end := start + pageSize
+	if end > len(results.Articles) {
+		end = len(results.Articles)
+	}
+
+	return Results{
+		Status:       results.Status,
+		TotalResults: results.TotalResults,
+		Articles:     results.Articles[start:end],
+	}
+}
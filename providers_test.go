@@ -0,0 +1,106 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func articleAt(url, title string, t time.Time) Article {
+	return Article{URL: url, Title: title, PublishedAt: t}
+}
+
+func TestMergeResultsDedupesByURLAndTitle(t *testing.T) {
+	now := time.Now()
+	all := []Results{
+		{Articles: []Article{
+			articleAt("https://a.example/1", "Same Title", now),
+			articleAt("https://a.example/2", "Unique A", now.Add(-time.Minute)),
+		}},
+		{Articles: []Article{
+			articleAt("https://a.example/1", "Same Title (dup URL)", now), // duplicate URL
+			articleAt("https://b.example/3", "same title", now),          // duplicate canonical title
+			articleAt("https://b.example/4", "Unique B", now.Add(time.Minute)),
+		}},
+	}
+
+	merged := mergeResults(all)
+
+	if merged.TotalResults != 3 {
+		t.Fatalf("expected 3 deduped articles, got %d", merged.TotalResults)
+	}
+	if len(merged.Articles) != 3 {
+		t.Fatalf("expected 3 articles in slice, got %d", len(merged.Articles))
+	}
+}
+
+func TestMergeResultsSortsNewestFirst(t *testing.T) {
+	now := time.Now()
+	all := []Results{
+		{Articles: []Article{
+			articleAt("https://a.example/old", "Old", now.Add(-time.Hour)),
+			articleAt("https://a.example/new", "New", now),
+		}},
+	}
+
+	merged := mergeResults(all)
+
+	if len(merged.Articles) != 2 {
+		t.Fatalf("expected 2 articles, got %d", len(merged.Articles))
+	}
+	if merged.Articles[0].URL != "https://a.example/new" {
+		t.Fatalf("expected newest article first, got %q", merged.Articles[0].URL)
+	}
+}
+
+//page <= 0 used to slice Articles[-20:0] and panic; paginate must clamp instead
+func TestPaginateClampsNonPositivePage(t *testing.T) {
+	results := Results{
+		Status:       "ok",
+		TotalResults: 2,
+		Articles: []Article{
+			articleAt("https://a.example/1", "One", time.Now()),
+			articleAt("https://a.example/2", "Two", time.Now()),
+		},
+	}
+
+	for _, page := range []int{0, -1, -20} {
+		got := paginate(results, page, 20)
+		if len(got.Articles) != 2 {
+			t.Fatalf("page=%d: expected clamp to page 1 (2 articles), got %d", page, len(got.Articles))
+		}
+	}
+}
+
+func TestPaginatePastEndReturnsEmptyArticles(t *testing.T) {
+	results := Results{
+		Status:       "ok",
+		TotalResults: 2,
+		Articles: []Article{
+			articleAt("https://a.example/1", "One", time.Now()),
+			articleAt("https://a.example/2", "Two", time.Now()),
+		},
+	}
+
+	got := paginate(results, 5, 20)
+
+	if len(got.Articles) != 0 {
+		t.Fatalf("expected no articles past the end, got %d", len(got.Articles))
+	}
+	if got.TotalResults != 2 {
+		t.Fatalf("expected TotalResults to still report the full set, got %d", got.TotalResults)
+	}
+}
+
+func TestPaginateSlicesRequestedWindow(t *testing.T) {
+	var articles []Article
+	for i := 0; i < 25; i++ {
+		articles = append(articles, articleAt("https://a.example/"+string(rune('a'+i)), "Title", time.Now()))
+	}
+	results := Results{Status: "ok", TotalResults: len(articles), Articles: articles}
+
+	page2 := paginate(results, 2, 20)
+
+	if len(page2.Articles) != 5 {
+		t.Fatalf("expected 5 articles on page 2 of 25 (pageSize 20), got %d", len(page2.Articles))
+	}
+}